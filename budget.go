@@ -0,0 +1,125 @@
+package retry
+
+import (
+	"sync"
+	"time"
+)
+
+// Budget caps the retry cost that many Iters sharing it may spend, so a
+// fleet of clients can't amplify an outage even when each client's own
+// backoff settings look reasonable.
+type Budget interface {
+	// Allow reports whether a retry may proceed, consuming budget if so.
+	Allow() bool
+	// Success records a successful attempt. Budgets that track the
+	// success/failure ratio use this to replenish; others may ignore it.
+	Success()
+}
+
+// tokenBudget is a token-bucket Budget: it allows up to burst retries
+// immediately, then refills at ratePerSec.
+type tokenBudget struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBudget returns a Budget that allows bursts of up to burst
+// retries and refills at ratePerSec thereafter.
+func NewTokenBudget(ratePerSec float64, burst int) Budget {
+	return &tokenBudget{
+		rate:   ratePerSec,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+func (b *tokenBudget) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (b *tokenBudget) Success() {}
+
+// ratioBudgetDecayThreshold bounds how large successes/failures may grow.
+// Once either crosses it, both are halved, preserving their ratio while
+// keeping the budget responsive to recent history: without this, a
+// long healthy run lets successes accumulate without limit, and a
+// subsequent outage would then need a matching flood of failures before
+// ever being throttled.
+const ratioBudgetDecayThreshold = 1000
+
+// ratioBudget is a Budget in the style of gRPC's retry throttling: a
+// retry is allowed only while successes*ratio > failures, which caps
+// retry volume at a multiple of the success rate. minPerSec is enforced
+// by an underlying token budget so a client isn't wedged at zero retries
+// forever after a cold start or a quiet period.
+type ratioBudget struct {
+	mu        sync.Mutex
+	ratio     float64
+	floor     *tokenBudget
+	successes float64
+	failures  float64
+}
+
+// NewRatioBudget returns a Budget that allows a retry only while
+// successes*ratio > failures, with a floor of minPerSec retries per
+// second allowed regardless of that ratio.
+func NewRatioBudget(ratio float64, minPerSec float64) Budget {
+	// The floor's bucket holds at least one token, so a fractional
+	// minPerSec (e.g. 0.5) still yields a retry once a token accrues.
+	burst := minPerSec
+	if burst < 1 {
+		burst = 1
+	}
+	return &ratioBudget{
+		ratio: ratio,
+		floor: &tokenBudget{rate: minPerSec, burst: burst, tokens: minPerSec, last: time.Now()},
+	}
+}
+
+func (b *ratioBudget) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.successes*b.ratio > b.failures || b.floor.Allow() {
+		b.failures++
+		b.decayLocked()
+		return true
+	}
+	return false
+}
+
+func (b *ratioBudget) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.successes++
+	b.decayLocked()
+}
+
+// decayLocked halves successes and failures once either grows past
+// ratioBudgetDecayThreshold, so the budget reflects recent history
+// instead of accumulating for the life of the process. Callers must
+// hold b.mu.
+func (b *ratioBudget) decayLocked() {
+	if b.successes > ratioBudgetDecayThreshold || b.failures > ratioBudgetDecayThreshold {
+		b.successes /= 2
+		b.failures /= 2
+	}
+}
@@ -0,0 +1,66 @@
+package retry
+
+import "testing"
+
+func TestTokenBudgetLimitsBurst(t *testing.T) {
+	b := NewTokenBudget(0, 2)
+
+	if !b.Allow() || !b.Allow() {
+		t.Fatal("expected the initial burst to be allowed")
+	}
+	if b.Allow() {
+		t.Error("expected the budget to be exhausted after the burst")
+	}
+}
+
+func TestRatioBudgetDeniesWithoutSuccesses(t *testing.T) {
+	b := NewRatioBudget(1, 0)
+
+	if b.Allow() {
+		t.Error("expected no retries to be allowed before any successes")
+	}
+
+	b.Success()
+	if !b.Allow() {
+		t.Error("expected a retry to be allowed after a success raised the ratio")
+	}
+	if b.Allow() {
+		t.Error("expected the ratio to be exhausted after one retry per success")
+	}
+}
+
+func TestRatioBudgetFloorAllowsMinimum(t *testing.T) {
+	b := NewRatioBudget(1, 1)
+
+	if !b.Allow() {
+		t.Error("expected the floor to allow a retry even with no successes yet")
+	}
+}
+
+// TestRatioBudgetDoesNotAccumulateForever guards against successes and
+// failures growing without bound: a client that's been healthy for a
+// long time must be throttled about as quickly as a fresh one once an
+// outage starts, not need a matching flood of failures to catch up with
+// however many successes happened to accumulate over the process's
+// lifetime.
+func TestRatioBudgetDoesNotAccumulateForever(t *testing.T) {
+	countDenial := func(b Budget, successes int) int {
+		for n := 0; n < successes; n++ {
+			b.Success()
+		}
+		for n := 0; n < successes+1; n++ {
+			if !b.Allow() {
+				return n
+			}
+		}
+		t.Fatalf("budget never denied a retry after %d successes", successes)
+		return 0
+	}
+
+	short := countDenial(NewRatioBudget(1, 0), 100)
+	long := countDenial(NewRatioBudget(1, 0), 100000)
+
+	if long > short*10 {
+		t.Errorf("got denial after %d allows following 100000 successes, vs %d following 100; want bounded growth, not proportional to total successes", long, short)
+	}
+}
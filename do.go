@@ -0,0 +1,144 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Decision tells Do or DoValue what to do after fn has returned an error.
+// Use the Retry and Abort values, or RetryAfter to override the
+// strategy's computed backoff for one iteration (e.g. to honor a 429's
+// Retry-After header).
+type Decision struct {
+	abort    bool
+	after    time.Duration
+	hasAfter bool
+}
+
+var (
+	// Retry retries using the strategy's own computed backoff.
+	Retry = Decision{}
+	// Abort stops retrying and returns the error as-is.
+	Abort = Decision{abort: true}
+)
+
+// RetryAfter retries after d, bypassing the strategy's computed delay for
+// this one iteration. d is still clamped to whatever is left of
+// Strategy.MaxDuration and ctx's deadline.
+func RetryAfter(d time.Duration) Decision {
+	return Decision{after: d, hasAfter: true}
+}
+
+// Classifier decides how Do and DoValue should respond to an error
+// returned by fn.
+type Classifier func(err error) Decision
+
+// permanentError marks an error as non-retryable. Wrap an error with
+// Permanent to abort a Do/DoValue loop immediately instead of retrying.
+type permanentError struct {
+	err error
+}
+
+// Permanent wraps err so that Do and DoValue stop retrying and return it
+// (unwrapped) right away.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+func (p *permanentError) Error() string { return p.err.Error() }
+func (p *permanentError) Unwrap() error { return p.err }
+
+func isPermanent(err error) (error, bool) {
+	var p *permanentError
+	if errors.As(err, &p) {
+		return p.err, true
+	}
+	return nil, false
+}
+
+// Do calls fn, retrying according to s until fn succeeds, fn returns an
+// error wrapped with Permanent, s's Classifier aborts the loop, s's
+// Budget denies a retry, or s's limits (MaxCount, MaxDuration) or ctx
+// are exhausted. attempt starts at
+// 1 and increments on every call to fn.
+func Do(ctx context.Context, s *Strategy, fn func(attempt int) error) error {
+	_, err := DoValue(ctx, s, func(attempt int) (struct{}, error) {
+		return struct{}{}, fn(attempt)
+	})
+	return err
+}
+
+// DoValue is Do for functions that also produce a value on success.
+func DoValue[T any](ctx context.Context, s *Strategy, fn func(attempt int) (T, error)) (T, error) {
+	var zero T
+	i := s.Start()
+
+	for attempt := 1; ; attempt++ {
+		v, err := fn(attempt)
+		elapsed := time.Since(i.start)
+		if s.Observer != nil {
+			s.Observer.OnAttempt(attempt, elapsed)
+		}
+		if err == nil {
+			if s.Budget != nil {
+				s.Budget.Success()
+			}
+			if s.Observer != nil {
+				s.Observer.OnStop(StoppedNone, attempt, elapsed)
+			}
+			return v, nil
+		}
+
+		if unwrapped, ok := isPermanent(err); ok {
+			if s.Observer != nil {
+				s.Observer.OnStop(StoppedAborted, attempt, elapsed)
+			}
+			return zero, unwrapped
+		}
+
+		decision := Retry
+		if s.Classifier != nil {
+			decision = s.Classifier(err)
+		}
+		if decision.abort {
+			if s.Observer != nil {
+				s.Observer.OnStop(StoppedAborted, attempt, elapsed)
+			}
+			return zero, err
+		}
+
+		resolvedCtx, delay, ok := i.advance(ctx)
+		if !ok {
+			if s.Observer != nil {
+				s.Observer.OnStop(i.Reason(), attempt, time.Since(i.start))
+			}
+			return zero, err
+		}
+		if decision.hasAfter {
+			// RetryAfter replaces the computed delay, longer or shorter,
+			// but is clamped again so it never extends past the deadline.
+			delay, ok = i.clampToDeadline(resolvedCtx, decision.after)
+			if !ok {
+				if s.Observer != nil {
+					s.Observer.OnStop(i.Reason(), attempt, time.Since(i.start))
+				}
+				return zero, err
+			}
+		}
+
+		if s.Observer != nil {
+			s.Observer.OnRetry(attempt, delay, err)
+		}
+
+		if !i.sleep(resolvedCtx, delay) {
+			if s.Observer != nil {
+				s.Observer.OnStop(i.Reason(), attempt, time.Since(i.start))
+			}
+			return zero, err
+		}
+	}
+}
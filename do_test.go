@@ -0,0 +1,223 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDoValueRetriesUntilSuccess(t *testing.T) {
+	s := &Strategy{Delay: time.Millisecond, MaxCount: 5}
+	attempts := 0
+
+	got, err := DoValue(context.Background(), s, func(attempt int) (int, error) {
+		attempts++
+		if attempt < 3 {
+			return 0, errors.New("not yet")
+		}
+		return 42, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != 42 {
+		t.Errorf("got %d, want 42", got)
+	}
+	if attempts != 3 {
+		t.Errorf("got %d attempts, want 3", attempts)
+	}
+}
+
+func TestDoMaxCountCapsTotalAttempts(t *testing.T) {
+	s := &Strategy{Delay: time.Millisecond, MaxCount: 3}
+	attempts := 0
+
+	err := Do(context.Background(), s, func(attempt int) error {
+		attempts++
+		return errors.New("always fails")
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if attempts != 3 {
+		t.Errorf("got %d total attempts, want %d", attempts, s.MaxCount)
+	}
+}
+
+func TestDoPermanentAbortsImmediately(t *testing.T) {
+	s := &Strategy{Delay: time.Millisecond, MaxCount: 5}
+	wantErr := errors.New("boom")
+	attempts := 0
+
+	err := Do(context.Background(), s, func(attempt int) error {
+		attempts++
+		return Permanent(wantErr)
+	})
+	if !errors.Is(err, wantErr) {
+		t.Errorf("got %v, want %v", err, wantErr)
+	}
+	if attempts != 1 {
+		t.Errorf("got %d attempts, want 1", attempts)
+	}
+}
+
+func TestDoClassifierAbort(t *testing.T) {
+	s := &Strategy{
+		Delay:      time.Millisecond,
+		MaxCount:   5,
+		Classifier: func(err error) Decision { return Abort },
+	}
+	attempts := 0
+
+	err := Do(context.Background(), s, func(attempt int) error {
+		attempts++
+		return errors.New("nope")
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if attempts != 1 {
+		t.Errorf("got %d attempts, want 1", attempts)
+	}
+}
+
+func TestDoClassifierRetryAfter(t *testing.T) {
+	s := &Strategy{Delay: time.Hour, MaxCount: 2}
+	s.Classifier = func(err error) Decision { return RetryAfter(time.Millisecond) }
+	attempts := 0
+
+	start := time.Now()
+	err := Do(context.Background(), s, func(attempt int) error {
+		attempts++
+		if attempt == 1 {
+			return errors.New("retry me")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("took %v, RetryAfter should have overridden the hour-long delay", elapsed)
+	}
+}
+
+// TestDoClassifierRetryAfterClampedToDeadline checks that a Classifier's
+// RetryAfter override is still clamped to the deadline and never extends
+// the wait past MaxDuration: otherwise a Classifier could defeat
+// MaxDuration entirely.
+func TestDoClassifierRetryAfterClampedToDeadline(t *testing.T) {
+	s := &Strategy{
+		Delay:       time.Millisecond,
+		MaxDuration: 50 * time.Millisecond,
+		Classifier:  func(err error) Decision { return RetryAfter(500 * time.Millisecond) },
+	}
+
+	start := time.Now()
+	err := Do(context.Background(), s, func(attempt int) error {
+		return errors.New("always fails")
+	})
+	if err == nil {
+		t.Fatal("expected error once MaxDuration is exhausted")
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("took %v, RetryAfter should not have extended the wait past MaxDuration", elapsed)
+	}
+}
+
+// countingBudget records how many times Allow and Success are called, and
+// optionally denies every retry, for testing that Do/DoValue wire a
+// Strategy.Budget through correctly.
+type countingBudget struct {
+	deny      bool
+	allows    int
+	successes int
+}
+
+func (b *countingBudget) Allow() bool {
+	b.allows++
+	return !b.deny
+}
+
+func (b *countingBudget) Success() { b.successes++ }
+
+func TestDoBudgetExhausted(t *testing.T) {
+	budget := &countingBudget{deny: true}
+	obs := &recordingObserver{}
+	s := &Strategy{Delay: time.Millisecond, MaxCount: 5, Budget: budget, Observer: obs}
+
+	err := Do(context.Background(), s, func(attempt int) error {
+		return errors.New("always fails")
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if budget.allows != 1 {
+		t.Errorf("got %d Budget.Allow calls, want 1", budget.allows)
+	}
+	if obs.stopReason != StoppedBudgetExhausted {
+		t.Errorf("got stop reason %v, want StoppedBudgetExhausted", obs.stopReason)
+	}
+}
+
+func TestDoBudgetSuccessRecorded(t *testing.T) {
+	budget := &countingBudget{}
+	s := &Strategy{Delay: time.Millisecond, MaxCount: 5, Budget: budget}
+	attempts := 0
+
+	err := Do(context.Background(), s, func(attempt int) error {
+		attempts++
+		if attempt < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if budget.successes != 1 {
+		t.Errorf("got %d Budget.Success calls, want 1", budget.successes)
+	}
+	if budget.allows != 2 {
+		t.Errorf("got %d Budget.Allow calls, want 2", budget.allows)
+	}
+}
+
+type recordingObserver struct {
+	attempts   int
+	retries    int
+	stopReason StopReason
+}
+
+func (o *recordingObserver) OnAttempt(attempt int, elapsed time.Duration) { o.attempts++ }
+func (o *recordingObserver) OnRetry(attempt int, delay time.Duration, err error) {
+	o.retries++
+}
+func (o *recordingObserver) OnStop(reason StopReason, totalAttempts int, totalElapsed time.Duration) {
+	o.stopReason = reason
+}
+
+func TestDoObserverHooks(t *testing.T) {
+	obs := &recordingObserver{}
+	s := &Strategy{Delay: time.Millisecond, MaxCount: 5, Observer: obs}
+
+	err := Do(context.Background(), s, func(attempt int) error {
+		if attempt < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if obs.attempts != 3 {
+		t.Errorf("got %d OnAttempt calls, want 3", obs.attempts)
+	}
+	if obs.retries != 2 {
+		t.Errorf("got %d OnRetry calls, want 2", obs.retries)
+	}
+	if obs.stopReason != StoppedNone {
+		t.Errorf("got stop reason %v, want StoppedNone", obs.stopReason)
+	}
+}
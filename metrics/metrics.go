@@ -0,0 +1,67 @@
+// Package metrics adapts a retry.Observer to Prometheus-style counters
+// and histograms, so retry activity shows up next to everything else a
+// service scrapes.
+//
+// The package does not import the Prometheus client library; it only
+// depends on the small Counter, CounterVec and Histogram interfaces
+// below. prometheus.Counter and prometheus.Histogram already satisfy
+// Counter and Histogram and can be passed straight in. A
+// *prometheus.CounterVec does not satisfy CounterVec as-is, because its
+// WithLabelValues returns prometheus.Counter rather than this package's
+// Counter; it needs a small wrapper type. Implement the interfaces
+// yourself to route into OpenTelemetry, logs, or anything else.
+package metrics
+
+import (
+	"time"
+
+	"gopherine/retry"
+)
+
+// Counter is the subset of prometheus.Counter that Observer needs.
+type Counter interface {
+	Inc()
+}
+
+// CounterVec is the subset of prometheus.CounterVec that Observer needs.
+type CounterVec interface {
+	WithLabelValues(lvs ...string) Counter
+}
+
+// Histogram is the subset of prometheus.Histogram that Observer needs.
+type Histogram interface {
+	Observe(v float64)
+}
+
+// Observer implements retry.Observer, recording attempts, retry delays
+// and stop reasons. Any field may be left nil to skip that metric.
+type Observer struct {
+	// Attempts counts every call to fn, successful or not.
+	Attempts Counter
+	// RetryDelay observes each retry's delay, in seconds.
+	RetryDelay Histogram
+	// Stops counts how a Do/DoValue loop ended, labeled by
+	// retry.StopReason.String().
+	Stops CounterVec
+}
+
+// OnAttempt implements retry.Observer.
+func (o *Observer) OnAttempt(attempt int, elapsed time.Duration) {
+	if o.Attempts != nil {
+		o.Attempts.Inc()
+	}
+}
+
+// OnRetry implements retry.Observer.
+func (o *Observer) OnRetry(attempt int, delay time.Duration, err error) {
+	if o.RetryDelay != nil {
+		o.RetryDelay.Observe(delay.Seconds())
+	}
+}
+
+// OnStop implements retry.Observer.
+func (o *Observer) OnStop(reason retry.StopReason, totalAttempts int, totalElapsed time.Duration) {
+	if o.Stops != nil {
+		o.Stops.WithLabelValues(reason.String()).Inc()
+	}
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"gopherine/retry"
+)
+
+type fakeCounter struct{ count int }
+
+func (c *fakeCounter) Inc() { c.count++ }
+
+type fakeCounterVec struct {
+	counters map[string]*fakeCounter
+}
+
+func (v *fakeCounterVec) WithLabelValues(lvs ...string) Counter {
+	if v.counters == nil {
+		v.counters = map[string]*fakeCounter{}
+	}
+	c, ok := v.counters[lvs[0]]
+	if !ok {
+		c = &fakeCounter{}
+		v.counters[lvs[0]] = c
+	}
+	return c
+}
+
+type fakeHistogram struct{ observations []float64 }
+
+func (h *fakeHistogram) Observe(v float64) { h.observations = append(h.observations, v) }
+
+func TestObserverOnAttemptIncrementsCounter(t *testing.T) {
+	attempts := &fakeCounter{}
+	o := &Observer{Attempts: attempts}
+
+	o.OnAttempt(1, time.Millisecond)
+	o.OnAttempt(2, time.Millisecond)
+
+	if attempts.count != 2 {
+		t.Errorf("got %d, want 2", attempts.count)
+	}
+}
+
+func TestObserverOnRetryObservesDelay(t *testing.T) {
+	delays := &fakeHistogram{}
+	o := &Observer{RetryDelay: delays}
+
+	o.OnRetry(1, 500*time.Millisecond, nil)
+
+	if len(delays.observations) != 1 {
+		t.Fatalf("got %d observations, want 1", len(delays.observations))
+	}
+	if delays.observations[0] != 0.5 {
+		t.Errorf("got %v, want 0.5", delays.observations[0])
+	}
+}
+
+func TestObserverOnStopLabelsByReason(t *testing.T) {
+	stops := &fakeCounterVec{}
+	o := &Observer{Stops: stops}
+
+	o.OnStop(retry.StoppedMaxCount, 3, time.Second)
+	o.OnStop(retry.StoppedMaxCount, 3, time.Second)
+	o.OnStop(retry.StoppedNone, 1, time.Millisecond)
+
+	if stops.counters["max count"].count != 2 {
+		t.Errorf("got %d, want 2", stops.counters["max count"].count)
+	}
+	if stops.counters["none"].count != 1 {
+		t.Errorf("got %d, want 1", stops.counters["none"].count)
+	}
+}
+
+func TestObserverNilFieldsAreSkipped(t *testing.T) {
+	o := &Observer{}
+
+	o.OnAttempt(1, time.Millisecond)
+	o.OnRetry(1, time.Millisecond, nil)
+	o.OnStop(retry.StoppedNone, 1, time.Millisecond)
+}
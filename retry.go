@@ -0,0 +1,445 @@
+// Package retry implements backoff strategies for retrying operations.
+package retry
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Jitter selects how the computed delay is randomized between attempts.
+// Jittering spreads out retries from many clients so they don't all wake
+// up and hammer a recovering dependency at the same instant.
+type Jitter int
+
+const (
+	// JitterNone uses the computed delay as-is.
+	JitterNone Jitter = iota
+	// JitterFull picks a uniformly random delay in [0, d].
+	JitterFull
+	// JitterEqual picks a uniformly random delay in [d/2, d].
+	JitterEqual
+	// JitterDecorrelated picks a delay based on the previous sleep,
+	// growing or shrinking randomly while staying within MaxDelay.
+	JitterDecorrelated
+)
+
+// Strategy describes a backoff curve: how long to wait between attempts,
+// how that wait grows, and when to give up.
+type Strategy struct {
+	// Delay is the base delay before the first retry.
+	Delay time.Duration
+	// MaxDelay caps the computed delay. Zero means unlimited.
+	MaxDelay time.Duration
+	// Factor is the exponential growth factor applied per attempt.
+	Factor float64
+	// MaxCount caps the number of attempts. Zero means unlimited.
+	MaxCount int
+	// MaxDuration caps the total time spent retrying. Zero means unlimited.
+	MaxDuration time.Duration
+	// Regular disables exponential growth and always waits Delay.
+	Regular bool
+	// Jitter selects how the computed delay is randomized.
+	Jitter Jitter
+
+	// Classifier, if set, is consulted by Do and DoValue after a failed
+	// attempt to decide whether to retry, abort, or retry after a
+	// server-specified delay. If nil, every non-permanent error is
+	// retried using the strategy's own backoff.
+	Classifier Classifier
+
+	// Observer, if set, is notified of Do and DoValue's progress: every
+	// attempt, every retry, and how the loop eventually stopped.
+	Observer Observer
+
+	// Budget, if set, is consulted before every retry so that many
+	// Iters sharing it collectively cap how much retry traffic they
+	// generate.
+	Budget Budget
+}
+
+// Observer receives lifecycle notifications from Do and DoValue, so
+// callers can export metrics or logs without polling. Implementations
+// must not block; do any slow work (e.g. a network call) asynchronously.
+type Observer interface {
+	// OnAttempt is called once fn has returned, successfully or not.
+	OnAttempt(attempt int, elapsed time.Duration)
+	// OnRetry is called after a retryable error, right before sleeping
+	// for delay.
+	OnRetry(attempt int, delay time.Duration, err error)
+	// OnStop is called exactly once, when the loop stops for any
+	// reason. reason is StoppedNone on success.
+	OnStop(reason StopReason, totalAttempts int, totalElapsed time.Duration)
+}
+
+// Start begins a new iteration over the strategy.
+func (s *Strategy) Start() *Iter {
+	i := &Iter{}
+	i.Reset(s, nil)
+	return i
+}
+
+// StopReason identifies why an Iter stopped, so callers can tell retry
+// exhaustion apart from cancellation without inspecting ctx.Err().
+type StopReason int
+
+const (
+	// StoppedNone means the Iter has not stopped.
+	StoppedNone StopReason = iota
+	// StoppedMaxCount means Strategy.MaxCount attempts were used up.
+	StoppedMaxCount
+	// StoppedMaxDuration means Strategy.MaxDuration elapsed.
+	StoppedMaxDuration
+	// StoppedContext means the context passed to Next (or Reset) was done.
+	StoppedContext
+	// StoppedManual means Iter.Stop was called.
+	StoppedManual
+	// StoppedAborted means Do or DoValue gave up on an error that was
+	// wrapped with Permanent, or that a Classifier marked Abort.
+	StoppedAborted
+	// StoppedBudgetExhausted means Strategy.Budget denied the retry.
+	StoppedBudgetExhausted
+)
+
+func (r StopReason) String() string {
+	switch r {
+	case StoppedNone:
+		return "none"
+	case StoppedMaxCount:
+		return "max count"
+	case StoppedMaxDuration:
+		return "max duration"
+	case StoppedContext:
+		return "context done"
+	case StoppedManual:
+		return "manual stop"
+	case StoppedAborted:
+		return "aborted"
+	case StoppedBudgetExhausted:
+		return "budget exhausted"
+	default:
+		return "unknown"
+	}
+}
+
+// Iter tracks the state of a single run through a Strategy: how many
+// attempts have been made and when the run began. An Iter may be reused
+// across runs via Reset.
+type Iter struct {
+	// Rand, if set, is used to generate jittered delays. If nil, a
+	// source seeded from the current time is created lazily.
+	Rand *rand.Rand
+
+	strategy   *Strategy
+	ctx        context.Context
+	start      time.Time
+	attempt    int
+	prevSleep  time.Duration
+	reason     StopReason
+	manualStop bool
+}
+
+// Reset prepares i to run s from scratch, using ctx to detect
+// cancellation. ctx may be nil.
+func (i *Iter) Reset(s *Strategy, ctx context.Context) {
+	i.strategy = s
+	i.ctx = ctx
+	i.start = time.Now()
+	i.attempt = 0
+	i.prevSleep = 0
+	i.reason = StoppedNone
+	i.manualStop = false
+}
+
+// WasStopped reports whether any call to Next since the last Reset has
+// returned false, for any reason. Use Reason to tell them apart.
+func (i *Iter) WasStopped() bool {
+	return i.reason != StoppedNone
+}
+
+// Reason returns why the Iter last stopped, or StoppedNone if it hasn't.
+func (i *Iter) Reason() StopReason {
+	return i.reason
+}
+
+// Stop aborts the Iter: the next call to Next returns false with reason
+// StoppedManual, regardless of the strategy's own limits.
+func (i *Iter) Stop() {
+	i.manualStop = true
+}
+
+// Next blocks for the delay of the next attempt and reports whether the
+// caller should retry. It returns false once the strategy's limits are
+// exhausted, ctx is done, or Stop was called. ctx may be nil, in which
+// case the context passed to Reset is used instead.
+//
+// Next never sleeps past the effective deadline: it clamps the computed
+// delay to whatever is left of Strategy.MaxDuration and ctx's deadline,
+// and stops immediately once that remaining budget runs out.
+func (i *Iter) Next(ctx context.Context) bool {
+	ctx, delay, ok := i.advance(ctx)
+	if !ok {
+		return false
+	}
+	return i.sleep(ctx, delay)
+}
+
+// advance resolves ctx, checks the strategy's limits and, if the caller
+// may proceed, records the attempt and returns the delay it should wait
+// before making it. It does not itself sleep, so callers that need to
+// override the computed delay (e.g. Do honoring a Retry-After header)
+// can replace it, re-clamped via clampToDeadline, before sleeping.
+func (i *Iter) advance(ctx context.Context) (resolvedCtx context.Context, delay time.Duration, ok bool) {
+	ctx = i.resolveCtx(ctx)
+	if !i.checkLimits(ctx) {
+		return ctx, 0, false
+	}
+	return i.commit(ctx, i.nextDelay())
+}
+
+// resolveCtx substitutes the context passed to Reset when ctx is nil.
+func (i *Iter) resolveCtx(ctx context.Context) context.Context {
+	if ctx == nil {
+		return i.ctx
+	}
+	return ctx
+}
+
+// checkLimits reports whether another attempt may be made at all, i.e.
+// that MaxCount, Stop and ctx don't already rule it out. i.attempt only
+// counts attempts granted by the Iter itself, not the caller's initial
+// attempt made before ever calling Next, so MaxCount is compared against
+// i.attempt+1 to cap the total at MaxCount.
+func (i *Iter) checkLimits(ctx context.Context) bool {
+	s := i.strategy
+
+	if i.manualStop {
+		i.reason = StoppedManual
+		return false
+	}
+	if s.MaxCount > 0 && i.attempt+1 >= s.MaxCount {
+		i.reason = StoppedMaxCount
+		return false
+	}
+	if ctx != nil {
+		select {
+		case <-ctx.Done():
+			i.reason = StoppedContext
+			return false
+		default:
+		}
+	}
+	return true
+}
+
+// commit clamps delay to whatever is left of the effective deadline,
+// consults the budget, and if both allow it, records the attempt.
+func (i *Iter) commit(ctx context.Context, delay time.Duration) (context.Context, time.Duration, bool) {
+	delay, ok := i.clampToDeadline(ctx, delay)
+	if !ok {
+		return ctx, 0, false
+	}
+
+	if i.strategy.Budget != nil && !i.strategy.Budget.Allow() {
+		i.reason = StoppedBudgetExhausted
+		return ctx, 0, false
+	}
+
+	i.attempt++
+	return ctx, delay, true
+}
+
+// clampToDeadline caps delay to whatever is left of Strategy.MaxDuration
+// and ctx's deadline, reporting false (and setting i.reason) if that
+// budget is already exhausted.
+func (i *Iter) clampToDeadline(ctx context.Context, delay time.Duration) (time.Duration, bool) {
+	s := i.strategy
+
+	var remaining time.Duration
+	hasLimit := false
+	reason := StoppedNone
+	if s.MaxDuration > 0 {
+		remaining = s.MaxDuration - time.Since(i.start)
+		reason = StoppedMaxDuration
+		hasLimit = true
+	}
+	if ctx != nil {
+		if dl, ok := ctx.Deadline(); ok {
+			if ctxRemaining := time.Until(dl); !hasLimit || ctxRemaining < remaining {
+				remaining = ctxRemaining
+				reason = StoppedContext
+				hasLimit = true
+			}
+		}
+	}
+	if hasLimit {
+		if remaining <= 0 {
+			i.reason = reason
+			return 0, false
+		}
+		if delay > remaining {
+			delay = remaining
+		}
+	}
+	return delay, true
+}
+
+// sleep waits out delay, honoring ctx's cancellation if ctx is non-nil.
+func (i *Iter) sleep(ctx context.Context, delay time.Duration) bool {
+	if ctx == nil {
+		time.Sleep(delay)
+		return true
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		i.reason = StoppedContext
+		return false
+	}
+}
+
+// nextDelay computes the delay for the upcoming attempt, including any
+// jitter, and records it as the previous sleep for JitterDecorrelated.
+func (i *Iter) nextDelay() time.Duration {
+	s := i.strategy
+
+	var d time.Duration
+	if s.Regular {
+		d = s.Delay
+	} else {
+		d = time.Duration(float64(s.Delay) * math.Pow(s.Factor, float64(i.attempt)))
+	}
+	if s.MaxDelay > 0 && d > s.MaxDelay {
+		d = s.MaxDelay
+	}
+	if d < 0 {
+		d = 0
+	}
+
+	switch s.Jitter {
+	case JitterFull:
+		d = time.Duration(i.rng().Int63n(int64(d) + 1))
+	case JitterEqual:
+		half := int64(d) / 2
+		d = time.Duration(half + i.rng().Int63n(half+1))
+	case JitterDecorrelated:
+		prev := i.prevSleep
+		if prev == 0 {
+			prev = s.Delay
+		}
+		upper := int64(prev)*3 - int64(s.Delay) + 1
+		if upper < 1 {
+			upper = 1
+		}
+		sleep := i.rng().Int63n(upper) + int64(s.Delay)
+		if s.MaxDelay > 0 && sleep > int64(s.MaxDelay) {
+			sleep = int64(s.MaxDelay)
+		}
+		d = time.Duration(sleep)
+		i.prevSleep = d
+	}
+
+	return d
+}
+
+// rng returns the Iter's random source, lazily creating a time-seeded one
+// if Rand hasn't been set.
+func (i *Iter) rng() *rand.Rand {
+	if i.Rand == nil {
+		i.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return i.Rand
+}
+
+// ParseStrategy parses a space-separated list of key=value parameters
+// into a Strategy. Supported keys are delay, maxdelay, factor, maxcount,
+// maxduration, regular and jitter. delay is required; all others default
+// to their zero value.
+func ParseStrategy(s string) (*Strategy, error) {
+	var st Strategy
+	haveDelay := false
+
+	for _, field := range strings.Fields(s) {
+		key, val, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, fmt.Errorf("retry: malformed parameter %q", field)
+		}
+
+		switch key {
+		case "delay":
+			d, err := time.ParseDuration(val)
+			if err != nil {
+				return nil, fmt.Errorf("retry: invalid duration for delay: %w", err)
+			}
+			st.Delay = d
+			haveDelay = true
+		case "maxdelay":
+			d, err := time.ParseDuration(val)
+			if err != nil {
+				return nil, fmt.Errorf("retry: invalid duration for maxdelay: %w", err)
+			}
+			st.MaxDelay = d
+		case "factor":
+			f, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				return nil, fmt.Errorf("retry: invalid factor %q: %w", val, err)
+			}
+			st.Factor = f
+		case "maxcount":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return nil, fmt.Errorf("retry: invalid maxcount %q: %w", val, err)
+			}
+			st.MaxCount = n
+		case "maxduration":
+			d, err := time.ParseDuration(val)
+			if err != nil {
+				return nil, fmt.Errorf("retry: invalid duration for maxduration: %w", err)
+			}
+			st.MaxDuration = d
+		case "regular":
+			b, err := strconv.ParseBool(val)
+			if err != nil {
+				return nil, fmt.Errorf("retry: invalid regular %q: %w", val, err)
+			}
+			st.Regular = b
+		case "jitter":
+			j, err := parseJitter(val)
+			if err != nil {
+				return nil, fmt.Errorf("retry: invalid jitter %q: %w", val, err)
+			}
+			st.Jitter = j
+		default:
+			return nil, fmt.Errorf("retry: unknown parameter %q", key)
+		}
+	}
+
+	if !haveDelay {
+		return nil, fmt.Errorf("retry: delay is required")
+	}
+
+	return &st, nil
+}
+
+func parseJitter(s string) (Jitter, error) {
+	switch s {
+	case "none":
+		return JitterNone, nil
+	case "full":
+		return JitterFull, nil
+	case "equal":
+		return JitterEqual, nil
+	case "decorrelated":
+		return JitterDecorrelated, nil
+	default:
+		return 0, fmt.Errorf("unknown jitter mode %q", s)
+	}
+}
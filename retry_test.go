@@ -1,6 +1,8 @@
 package retry
 
 import (
+	"context"
+	"math/rand"
 	"testing"
 	"time"
 )
@@ -11,7 +13,7 @@ func TestIterTiming(t *testing.T) {
 		MaxDuration: 0.25e9,
 		Regular:     true,
 	}
-	want := []time.Duration{0, 0.1e9, 0.2e9, 0.2e9}
+	want := []time.Duration{0, 0.1e9, 0.2e9, 0.25e9, 0.25e9}
 	got := make([]time.Duration, 0, len(want))
 	t0 := time.Now()
 	i := testIter.Start()
@@ -24,8 +26,8 @@ func TestIterTiming(t *testing.T) {
 	}
 	got = append(got, time.Now().Sub(t0))
 
-	if i.WasStopped() {
-		t.Error("unexpected stop")
+	if !i.WasStopped() || i.Reason() != StoppedMaxDuration {
+		t.Errorf("got reason %v, want StoppedMaxDuration", i.Reason())
 	}
 
 	if len(got) != len(want) {
@@ -42,6 +44,143 @@ func TestIterTiming(t *testing.T) {
 	}
 }
 
+// TestIterMaxCount checks that MaxCount caps the total number of
+// attempts, counting the caller's initial attempt made before the loop
+// ever calls Next, not just the retries Next grants.
+func TestIterMaxCount(t *testing.T) {
+	s := &Strategy{Delay: time.Millisecond, MaxCount: 3}
+	i := s.Start()
+
+	attempts := 1 // the caller's initial attempt, made before any Next call
+	for i.Next(nil) {
+		attempts++
+	}
+
+	if attempts != 3 {
+		t.Errorf("got %d total attempts, want %d", attempts, s.MaxCount)
+	}
+	if i.Reason() != StoppedMaxCount {
+		t.Errorf("got reason %v, want StoppedMaxCount", i.Reason())
+	}
+}
+
+func TestIterStop(t *testing.T) {
+	s := &Strategy{Delay: time.Hour}
+	i := s.Start()
+
+	i.Stop()
+	if i.Next(nil) {
+		t.Error("expected Next to return false after Stop")
+	}
+	if i.Reason() != StoppedManual {
+		t.Errorf("got reason %v, want StoppedManual", i.Reason())
+	}
+}
+
+func TestIterContextCancel(t *testing.T) {
+	s := &Strategy{Delay: time.Hour}
+	i := s.Start()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if i.Next(ctx) {
+		t.Error("expected Next to return false for an already-canceled context")
+	}
+	if i.Reason() != StoppedContext {
+		t.Errorf("got reason %v, want StoppedContext", i.Reason())
+	}
+}
+
+func TestIterContextDeadlineElapsed(t *testing.T) {
+	s := &Strategy{Delay: time.Millisecond}
+	i := s.Start()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if !i.Next(ctx) {
+		t.Fatal("expected the first attempt to succeed well before the deadline")
+	}
+
+	<-ctx.Done()
+	if i.Next(ctx) {
+		t.Error("expected Next to return false once the context deadline passed")
+	}
+	if i.Reason() != StoppedContext {
+		t.Errorf("got reason %v, want StoppedContext", i.Reason())
+	}
+}
+
+// denyBudget is a Budget that never allows a retry, used to check that
+// Iter surfaces StoppedBudgetExhausted rather than sleeping anyway.
+type denyBudget struct{}
+
+func (denyBudget) Allow() bool { return false }
+func (denyBudget) Success()    {}
+
+func TestIterBudgetExhausted(t *testing.T) {
+	s := &Strategy{Delay: time.Millisecond, Budget: denyBudget{}}
+	i := s.Start()
+
+	if i.Next(nil) {
+		t.Error("expected Next to return false when the budget denies the retry")
+	}
+	if i.Reason() != StoppedBudgetExhausted {
+		t.Errorf("got reason %v, want StoppedBudgetExhausted", i.Reason())
+	}
+}
+
+func TestIterJitterFull(t *testing.T) {
+	s := &Strategy{Delay: 100 * time.Millisecond, Jitter: JitterFull}
+	i := &Iter{Rand: rand.New(rand.NewSource(1))}
+	i.Reset(s, nil)
+
+	d := i.nextDelay()
+	if d < 0 || d > s.Delay {
+		t.Errorf("got delay %v, want in [0, %v]", d, s.Delay)
+	}
+
+	// Same seed, same sequence: nextDelay is deterministic.
+	want := d
+	i.Reset(s, nil)
+	i.Rand = rand.New(rand.NewSource(1))
+	if got := i.nextDelay(); got != want {
+		t.Errorf("got %v, want %v (same seed should reproduce the same delay)", got, want)
+	}
+}
+
+func TestIterJitterEqual(t *testing.T) {
+	s := &Strategy{Delay: 100 * time.Millisecond, Jitter: JitterEqual}
+	i := &Iter{Rand: rand.New(rand.NewSource(2))}
+	i.Reset(s, nil)
+
+	for n := 0; n < 10; n++ {
+		d := i.nextDelay()
+		if d < s.Delay/2 || d > s.Delay {
+			t.Errorf("got delay %v, want in [%v, %v]", d, s.Delay/2, s.Delay)
+		}
+	}
+}
+
+func TestIterJitterDecorrelated(t *testing.T) {
+	s := &Strategy{Delay: 100 * time.Millisecond, MaxDelay: time.Second, Jitter: JitterDecorrelated}
+	i := &Iter{Rand: rand.New(rand.NewSource(3))}
+	i.Reset(s, nil)
+
+	prev := s.Delay
+	for n := 0; n < 10; n++ {
+		d := i.nextDelay()
+		if d < s.Delay || d > s.MaxDelay {
+			t.Errorf("got delay %v, want in [%v, %v]", d, s.Delay, s.MaxDelay)
+		}
+		if d > prev*3 {
+			t.Errorf("got delay %v, want at most 3x the previous sleep %v", d, prev)
+		}
+		prev = d
+	}
+}
+
 func TestParseStrategy(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -138,6 +277,38 @@ func TestParseStrategy(t *testing.T) {
 			},
 		},
 
+		// Jitter modes
+		{
+			name:  "jitter full",
+			input: "delay=100ms jitter=full",
+			want: Strategy{
+				Delay:  100 * time.Millisecond,
+				Jitter: JitterFull,
+			},
+		},
+		{
+			name:  "jitter equal",
+			input: "delay=100ms jitter=equal",
+			want: Strategy{
+				Delay:  100 * time.Millisecond,
+				Jitter: JitterEqual,
+			},
+		},
+		{
+			name:  "jitter decorrelated",
+			input: "delay=100ms jitter=decorrelated",
+			want: Strategy{
+				Delay:  100 * time.Millisecond,
+				Jitter: JitterDecorrelated,
+			},
+		},
+		{
+			name:        "invalid jitter",
+			input:       "delay=100ms jitter=bogus",
+			wantErr:     true,
+			errContains: "jitter",
+		},
+
 		// Error cases
 		{
 			name:        "invalid duration",
@@ -249,6 +420,9 @@ func compareStrategy(t *testing.T, got, want *Strategy) {
 	if got.Regular != want.Regular {
 		t.Errorf("Regular: got %v, want %v", got.Regular, want.Regular)
 	}
+	if got.Jitter != want.Jitter {
+		t.Errorf("Jitter: got %v, want %v", got.Jitter, want.Jitter)
+	}
 }
 
 func BenchmarkReuseIter(b *testing.B) {